@@ -0,0 +1,64 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package collector runs the periodic sweep that bounds S3 storage cost
+// for collected pipelinerun artifacts.
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/cluster/collector"
+	logutil "github.com/horizoncd/horizon/pkg/util/log"
+)
+
+// Controller periodically sweeps a Trasher: moving stale pipelinerun
+// artifacts to trash/, then permanently removing anything that's been
+// sitting there past the configured TrashLifetime.
+type Controller interface {
+	// Run blocks, sweeping every interval until ctx is cancelled.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type controller struct {
+	trasher *collector.Trasher
+}
+
+// NewController builds a Controller around trasher.
+func NewController(trasher *collector.Trasher) Controller {
+	return &controller{trasher: trasher}
+}
+
+func (c *controller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.sweepOnce(ctx)
+		}
+	}
+}
+
+func (c *controller) sweepOnce(ctx context.Context) {
+	if err := c.trasher.Sweep(ctx); err != nil {
+		logutil.Errorf(ctx, "collector trasher: sweep failed: %v", err)
+	}
+	if err := c.trasher.EmptyTrash(ctx); err != nil {
+		logutil.Errorf(ctx, "collector trasher: empty trash failed: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/cluster/collector"
+	"github.com/horizoncd/horizon/pkg/objectstore"
+)
+
+func TestControllerRunSweepsUntilCancelled(t *testing.T) {
+	store := objectstore.NewFake()
+	ctx := context.Background()
+	key := time.Now().Format("200601") + "/pr/app-1/cluster-2/name"
+	if err := store.PutObject(ctx, key, bytes.NewReader([]byte("data")), nil); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+
+	trasher := collector.NewTrasher(store, collector.TrasherConfig{RetentionDuration: 0}, nil)
+	c := NewController(trasher)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		c.Run(runCtx, time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.After(time.Second)
+	for {
+		if _, err := store.GetObject(ctx, key); err != nil {
+			break
+		}
+		select {
+		case <-deadline:
+			cancel()
+			<-done
+			t.Fatalf("Run did not sweep %q within the deadline", key)
+		default:
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,109 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awssts "github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/horizoncd/horizon/pkg/objectstore"
+)
+
+const (
+	// AuthMethodStatic uses a fixed access key/secret key pair.
+	AuthMethodStatic = "static"
+	// AuthMethodIAM uses the EC2 instance role, re-fetched by the SDK's
+	// credentials.Credentials on the first use within ExpiryWindow of expiry.
+	AuthMethodIAM = "iam"
+	// AuthMethodIRSA uses an EKS IAM role for a service account (IRSA), via
+	// STS AssumeRoleWithWebIdentity, also refreshed automatically.
+	AuthMethodIRSA = "irsa"
+
+	_envWebIdentityTokenFile = "AWS_WEB_IDENTITY_TOKEN_FILE"
+	_envRoleARN              = "AWS_ROLE_ARN"
+)
+
+// NewWithIAMRole builds an Interface whose credentials come from an EKS
+// service account's assumed role (IRSA) when AWS_ROLE_ARN and
+// AWS_WEB_IDENTITY_TOKEN_FILE are set, and otherwise from the EC2 instance
+// role -- either way refreshed by the SDK before they expire, so a
+// long-lived collector process never needs static keys.
+func NewWithIAMRole(bucket, region string, opts ...Option) (Interface, error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, err
+	}
+	creds, err := chooseCredentialsProvider(sess, &objectstore.S3Config{Region: region})
+	if err != nil {
+		return nil, err
+	}
+	sess.Config.Credentials = creds
+	return New(sess, bucket, opts...), nil
+}
+
+// chooseCredentialsProvider picks a credentials.Credentials chain for cfg,
+// mirroring the driver-selection pattern used for ObjectStore backends:
+// an explicit AuthMethod wins, otherwise the environment is probed.
+func chooseCredentialsProvider(sess *session.Session, cfg *objectstore.S3Config) (*credentials.Credentials, error) {
+	switch cfg.AuthMethod {
+	case AuthMethodStatic:
+		return credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, ""), nil
+	case AuthMethodIAM:
+		return credentials.NewCredentials(&ec2rolecreds.EC2RoleProvider{
+			Client:       ec2metadata.New(sess),
+			ExpiryWindow: 5 * time.Minute,
+		}), nil
+	case AuthMethodIRSA:
+		return irsaCredentials(sess)
+	default:
+		if os.Getenv(_envWebIdentityTokenFile) != "" && os.Getenv(_envRoleARN) != "" {
+			return irsaCredentials(sess)
+		}
+		if ec2metadata.New(sess).Available() {
+			return credentials.NewCredentials(&ec2rolecreds.EC2RoleProvider{
+				Client:       ec2metadata.New(sess),
+				ExpiryWindow: 5 * time.Minute,
+			}), nil
+		}
+		if cfg.AccessKey != "" || cfg.SecretKey != "" {
+			return credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, ""), nil
+		}
+		return nil, errors.New("s3: no AuthMethod set, no AccessKey/SecretKey configured, " +
+			"and no EC2 instance role or IRSA environment found")
+	}
+}
+
+// irsaCredentials builds a WebIdentityRoleProvider from the environment
+// variables the EKS pod-identity webhook injects (AWS_ROLE_ARN,
+// AWS_WEB_IDENTITY_TOKEN_FILE), so pods don't need any config of their own.
+func irsaCredentials(sess *session.Session) (*credentials.Credentials, error) {
+	roleARN := os.Getenv(_envRoleARN)
+	tokenFile := os.Getenv(_envWebIdentityTokenFile)
+	if roleARN == "" || tokenFile == "" {
+		return nil, errors.New("s3: AWS_ROLE_ARN/AWS_WEB_IDENTITY_TOKEN_FILE must be set to use AuthMethodIRSA")
+	}
+	provider := stscreds.NewWebIdentityRoleProvider(awssts.New(sess), roleARN, "horizon-collector", tokenFile)
+	provider.ExpiryWindow = 5 * time.Minute
+	return credentials.NewCredentials(provider), nil
+}
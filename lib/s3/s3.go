@@ -0,0 +1,251 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package s3 wraps the aws-sdk-go S3 client used by the tekton collector.
+// Uploads and downloads of pipelinerun logs/objects go through
+// s3manager.Uploader/Downloader so that large build logs never need to be
+// fully buffered in memory.
+package s3
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+	"github.com/horizoncd/horizon/pkg/objectstore"
+)
+
+func init() {
+	objectstore.Register("s3", func(cfg *objectstore.Config) (objectstore.ObjectStore, error) {
+		return NewFromConfig(&cfg.S3)
+	})
+}
+
+const (
+	// _defaultPartSize is the size of each multipart upload/download part,
+	// matching s3manager's own default.
+	_defaultPartSize = 5 * 1024 * 1024
+	// _defaultConcurrency is the number of parts uploaded/downloaded in parallel.
+	_defaultConcurrency = 5
+)
+
+// Interface is the object-storage contract the tekton collector is built
+// against. It is implemented here with the AWS SDK, backed by
+// s3manager.Uploader/Downloader so callers can stream arbitrarily large
+// objects instead of holding them in memory.
+type Interface interface {
+	PutObject(ctx context.Context, key string, body io.Reader, metadata map[string]string) error
+	GetObject(ctx context.Context, key string) ([]byte, error)
+	// GetObjectStream returns the object body as a stream. Callers must
+	// close the returned ReadCloser.
+	GetObjectStream(ctx context.Context, key string) (io.ReadCloser, error)
+	GetSignedObjectURL(key string, expire time.Duration) (string, error)
+	DeleteObject(ctx context.Context, key string) error
+	GetBucket(ctx context.Context) string
+	// ListObjects lists every object whose key starts with prefix.
+	ListObjects(ctx context.Context, prefix string) ([]objectstore.ObjectInfo, error)
+}
+
+// Option configures the uploader/downloader part size and concurrency used
+// by a client constructed with New.
+type Option func(*s3Client)
+
+// WithPartSize overrides the multipart upload/download part size, in bytes.
+func WithPartSize(partSize int64) Option {
+	return func(c *s3Client) {
+		if partSize > 0 {
+			c.partSize = partSize
+		}
+	}
+}
+
+// WithConcurrency overrides the number of parts uploaded/downloaded in parallel.
+func WithConcurrency(concurrency int) Option {
+	return func(c *s3Client) {
+		if concurrency > 0 {
+			c.concurrency = concurrency
+		}
+	}
+}
+
+type s3Client struct {
+	bucket     string
+	client     *s3.S3
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
+	// streamDownloader always runs with Concurrency 1: GetObjectStream feeds
+	// parts into an io.Pipe in order, which only holds if parts arrive sequentially.
+	streamDownloader *s3manager.Downloader
+	partSize         int64
+	concurrency      int
+}
+
+// NewFromConfig builds an Interface from an objectstore.S3Config, for use
+// by the "s3" driver registered with pkg/objectstore. cfg.AuthMethod picks
+// the credential provider (see chooseCredentialsProvider), so the same
+// driver config works whether the collector runs on a laptop, an EC2
+// instance, or an EKS pod with IRSA.
+func NewFromConfig(cfg *objectstore.S3Config) (Interface, error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(cfg.Region)})
+	if err != nil {
+		return nil, err
+	}
+	creds, err := chooseCredentialsProvider(sess, cfg)
+	if err != nil {
+		return nil, err
+	}
+	sess.Config.Credentials = creds
+
+	var opts []Option
+	if cfg.PartSize > 0 {
+		opts = append(opts, WithPartSize(cfg.PartSize))
+	}
+	if cfg.Concurrency > 0 {
+		opts = append(opts, WithConcurrency(cfg.Concurrency))
+	}
+	return New(sess, cfg.Bucket, opts...), nil
+}
+
+// New creates an Interface backed by sess, using whatever credentials sess
+// carries (static keys, an EC2 instance role or IRSA).
+func New(sess *session.Session, bucket string, opts ...Option) Interface {
+	c := &s3Client{
+		bucket:      bucket,
+		client:      s3.New(sess),
+		partSize:    _defaultPartSize,
+		concurrency: _defaultConcurrency,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.uploader = s3manager.NewUploader(sess, func(u *s3manager.Uploader) {
+		u.PartSize = c.partSize
+		u.Concurrency = c.concurrency
+	})
+	c.downloader = s3manager.NewDownloader(sess, func(d *s3manager.Downloader) {
+		d.PartSize = c.partSize
+		d.Concurrency = c.concurrency
+	})
+	c.streamDownloader = s3manager.NewDownloader(sess, func(d *s3manager.Downloader) {
+		d.PartSize = c.partSize
+		d.Concurrency = 1
+	})
+	return c
+}
+
+func (c *s3Client) PutObject(ctx context.Context, key string, body io.Reader, metadata map[string]string) error {
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if len(metadata) > 0 {
+		input.Metadata = aws.StringMap(metadata)
+	}
+	_, err := c.uploader.UploadWithContext(ctx, input)
+	return err
+}
+
+func (c *s3Client) GetObject(ctx context.Context, key string) ([]byte, error) {
+	buf := aws.NewWriteAtBuffer(nil)
+	if _, err := c.downloader.DownloadWithContext(ctx, buf, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		return nil, translateNotFound(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// translateNotFound maps the AWS-specific NoSuchKey error code onto
+// objectstore.ErrNotFound, so callers of the ObjectStore interface don't
+// need to know they're talking to S3.
+func translateNotFound(err error) error {
+	if e, ok := err.(awserr.Error); ok && e.Code() == s3.ErrCodeNoSuchKey {
+		return objectstore.ErrNotFound
+	}
+	return err
+}
+
+// GetObjectStream downloads an object through the downloader without
+// buffering it whole: parts land on a pipe as they're fetched and are
+// handed to the caller as they arrive.
+func (c *s3Client) GetObjectStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, w := io.Pipe()
+	go func() {
+		_, err := c.streamDownloader.DownloadWithContext(ctx, &pipeWriterAt{w: w}, &s3.GetObjectInput{
+			Bucket: aws.String(c.bucket),
+			Key:    aws.String(key),
+		})
+		_ = w.CloseWithError(translateNotFound(err))
+	}()
+	return r, nil
+}
+
+// pipeWriterAt adapts an io.PipeWriter to io.WriterAt so it can be used as
+// the destination for s3manager.Downloader. It only supports sequential,
+// non-overlapping writes, which holds as long as Concurrency is 1.
+type pipeWriterAt struct {
+	w *io.PipeWriter
+}
+
+func (p *pipeWriterAt) WriteAt(b []byte, _ int64) (int, error) {
+	return p.w.Write(b)
+}
+
+func (c *s3Client) GetSignedObjectURL(key string, expire time.Duration) (string, error) {
+	req, _ := c.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	return req.Presign(expire)
+}
+
+func (c *s3Client) DeleteObject(ctx context.Context, key string) error {
+	_, err := c.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	return translateNotFound(err)
+}
+
+func (c *s3Client) GetBucket(_ context.Context) string {
+	return c.bucket
+}
+
+func (c *s3Client) ListObjects(ctx context.Context, prefix string) ([]objectstore.ObjectInfo, error) {
+	var infos []objectstore.ObjectInfo
+	err := c.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, obj := range page.Contents {
+			infos = append(infos, objectstore.ObjectInfo{
+				Key:          aws.StringValue(obj.Key),
+				LastModified: aws.TimeValue(obj.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	return infos, nil
+}
@@ -0,0 +1,206 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/horizoncd/horizon/pkg/objectstore"
+)
+
+// fakeS3Server is just enough of the S3 HTTP API -- PutObject, ranged
+// GetObject, DeleteObject, ListObjectsV2 -- for the aws-sdk-go client New
+// builds (s3manager.Uploader/Downloader included) to round-trip against,
+// without standing up a real S3-compatible service.
+type fakeS3Server struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3Server(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := &fakeS3Server{objects: map[string][]byte{}}
+	ts := httptest.NewServer(http.HandlerFunc(srv.handle))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (s *fakeS3Server) handle(w http.ResponseWriter, r *http.Request) {
+	// Path-style addressing puts the bucket as the first path segment and
+	// the key as the rest: /<bucket>/<key...>.
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if i := strings.Index(key, "/"); i >= 0 {
+		key = key[i+1:]
+	} else {
+		key = ""
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		s.listObjectsV2(w, r)
+	case r.Method == http.MethodPut && key != "":
+		s.putObject(w, r, key)
+	case r.Method == http.MethodGet && key != "":
+		s.getObject(w, r, key)
+	case r.Method == http.MethodDelete && key != "":
+		s.deleteObject(w, key)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *fakeS3Server) putObject(w http.ResponseWriter, r *http.Request, key string) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	s.objects[key] = b
+	s.mu.Unlock()
+}
+
+func (s *fakeS3Server) getObject(w http.ResponseWriter, r *http.Request, key string) {
+	s.mu.Lock()
+	b, ok := s.objects[key]
+	s.mu.Unlock()
+	if !ok {
+		s.notFound(w)
+		return
+	}
+
+	start, end := 0, len(b)-1
+	if rng := r.Header.Get("Range"); rng != "" {
+		var rs, re int
+		if _, err := fmt.Sscanf(rng, "bytes=%d-%d", &rs, &re); err == nil {
+			start = rs
+			if re < end {
+				end = re
+			}
+		}
+	}
+	chunk := b[start : end+1]
+
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(b)))
+	w.Header().Set("Content-Length", strconv.Itoa(len(chunk)))
+	w.WriteHeader(http.StatusPartialContent)
+	_, _ = w.Write(chunk)
+}
+
+func (s *fakeS3Server) deleteObject(w http.ResponseWriter, key string) {
+	s.mu.Lock()
+	delete(s.objects, key)
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *fakeS3Server) listObjectsV2(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	s.mu.Lock()
+	var contents strings.Builder
+	for key, b := range s.objects {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		fmt.Fprintf(&contents, "<Contents><Key>%s</Key>"+
+			"<LastModified>2023-01-01T00:00:00.000Z</LastModified>"+
+			"<ETag>&quot;x&quot;</ETag><Size>%d</Size>"+
+			"<StorageClass>STANDARD</StorageClass></Contents>", key, len(b))
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/xml")
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>`+
+		`<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`+
+		`<Name>test-bucket</Name><Prefix>%s</Prefix><MaxKeys>1000</MaxKeys>`+
+		`<IsTruncated>false</IsTruncated>%s</ListBucketResult>`, prefix, contents.String())
+}
+
+func (s *fakeS3Server) notFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>`+
+		`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+}
+
+func newTestSession(t *testing.T, endpoint string) *session.Session {
+	t.Helper()
+	sess, err := session.NewSession(&aws.Config{
+		Region:           aws.String("us-east-1"),
+		Endpoint:         aws.String(endpoint),
+		Credentials:      credentials.AnonymousCredentials,
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	if err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+	return sess
+}
+
+// TestS3RoundTripPutGetObjectStream checks that PutObject followed by
+// GetObjectStream reconstructs the original bytes in order, even when the
+// download is split across several ranged GETs: pipeWriterAt.WriteAt
+// ignores the offset s3manager.Downloader passes it and relies entirely on
+// streamDownloader running at Concurrency 1 to keep writes sequential.
+func TestS3RoundTripPutGetObjectStream(t *testing.T) {
+	ts := newFakeS3Server(t)
+	sess := newTestSession(t, ts.URL)
+	ctx := context.Background()
+	const content = "the quick brown fox jumps over the lazy dog"
+
+	store := New(sess, "test-bucket")
+	if err := store.PutObject(ctx, "pr-log/key1", strings.NewReader(content), nil); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+
+	// A small part size forces the download into several sequential ranged
+	// GETs instead of one, which is what exercises WriteAt's offset-blind
+	// sequencing.
+	chunked := New(sess, "test-bucket", WithPartSize(8), WithConcurrency(4))
+	rc, err := chunked.GetObjectStream(ctx, "pr-log/key1")
+	if err != nil {
+		t.Fatalf("GetObjectStream: %v", err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read stream: %v", err)
+	}
+	if string(got) != content {
+		t.Fatalf("GetObjectStream = %q, want %q", got, content)
+	}
+}
+
+// TestS3ConformsToObjectStore runs the same Put/Get/List/Delete assertions
+// fake_test.go and filesystem_test.go run for their drivers, against the
+// s3 driver's real s3manager-backed client instead of re-deriving them.
+func TestS3ConformsToObjectStore(t *testing.T) {
+	ts := newFakeS3Server(t)
+	sess := newTestSession(t, ts.URL)
+	objectstore.ConformanceTest(t, New(sess, "test-bucket"))
+}
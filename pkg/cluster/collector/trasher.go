@@ -0,0 +1,202 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package collector bounds the storage cost of collected pipelinerun
+// artifacts: a Trasher moves objects past their retention window into a
+// trash/ prefix instead of deleting them outright, and permanently removes
+// them only after they've sat in trash for TrashLifetime.
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/objectstore"
+	logutil "github.com/horizoncd/horizon/pkg/util/log"
+)
+
+const (
+	_trashPrefix = "trash/"
+
+	// _collectedSegmentPr and _collectedSegmentPrLog are the second path
+	// segment of a collected key: "<YYYYMM>/pr/..." or
+	// "<YYYYMM>/pr-log/..." (see getPathForPr/getPathForPrLog in
+	// pkg/cluster/tekton/collector).
+	_collectedSegmentPr    = "pr"
+	_collectedSegmentPrLog = "pr-log"
+
+	_monthFormat = "200601"
+)
+
+// TrasherConfig configures how long a collected object lives before it's
+// trashed, and how long it then sits in trash before being permanently
+// removed.
+type TrasherConfig struct {
+	// RetentionDuration is how long after collection an object is left in
+	// place before Sweep moves it to trash/.
+	RetentionDuration time.Duration
+	// TrashLifetime is how long an object sits under trash/ before
+	// EmptyTrash permanently deletes it.
+	TrashLifetime time.Duration
+	// UnsafeDelete, when true, skips the trash stage entirely: Sweep
+	// deletes objects past RetentionDuration immediately. Operators must
+	// opt into this explicitly; it is not reversible.
+	UnsafeDelete bool
+}
+
+// MetricsRecorder receives the outcome of each object-store call a Trasher
+// makes, so a caller can fold trash/sweep traffic into its own
+// instrumentation (e.g. the tekton collector's s3_requests_total).
+type MetricsRecorder interface {
+	ObserveRequest(op string, err error)
+}
+
+type noopMetricsRecorder struct{}
+
+func (noopMetricsRecorder) ObserveRequest(string, error) {}
+
+// Trasher sweeps a Collector's object store for artifacts old enough to
+// retire, moving them to trash/ (two-phase) or deleting them outright
+// (UnsafeDelete), and permanently empties trash/ once TrashLifetime elapses.
+type Trasher struct {
+	store   objectstore.ObjectStore
+	cfg     TrasherConfig
+	metrics MetricsRecorder
+}
+
+// NewTrasher builds a Trasher around store, configured by cfg. metrics may
+// be nil, in which case object-store calls go unrecorded.
+func NewTrasher(store objectstore.ObjectStore, cfg TrasherConfig, metrics MetricsRecorder) *Trasher {
+	if metrics == nil {
+		metrics = noopMetricsRecorder{}
+	}
+	return &Trasher{store: store, cfg: cfg, metrics: metrics}
+}
+
+// Sweep lists the collected objects in every month a not-yet-retired
+// object could still be sitting in, and trashes (or, with UnsafeDelete,
+// deletes) the ones older than RetentionDuration.
+func (t *Trasher) Sweep(ctx context.Context) error {
+	for _, month := range sweepMonths(time.Now(), t.cfg.RetentionDuration) {
+		for _, segment := range []string{_collectedSegmentPr, _collectedSegmentPrLog} {
+			prefix := month + "/" + segment + "/"
+			objs, err := t.store.ListObjects(ctx, prefix)
+			if err != nil {
+				return fmt.Errorf("trasher: failed to list %s: %w", prefix, err)
+			}
+			for _, obj := range objs {
+				if time.Since(obj.LastModified) < t.cfg.RetentionDuration {
+					continue
+				}
+				if t.cfg.UnsafeDelete {
+					err := t.store.DeleteObject(ctx, obj.Key)
+					t.metrics.ObserveRequest("delete", err)
+					if err != nil {
+						return fmt.Errorf("trasher: failed to delete %s: %w", obj.Key, err)
+					}
+					continue
+				}
+				if err := t.trash(ctx, obj.Key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sweepMonths returns the "<YYYYMM>" prefixes Sweep should list: every
+// month from now back through retention, plus one month of slack so an
+// object collected right at a month boundary isn't missed. This keeps
+// Sweep's listing bounded by RetentionDuration instead of scanning the
+// whole store on every tick.
+func sweepMonths(now time.Time, retention time.Duration) []string {
+	n := int(retention/(30*24*time.Hour)) + 2
+	months := make([]string, 0, n)
+	// Step back from the first of the month: AddDate(0, -1, 0) from the 29th-31st
+	// would otherwise normalize into the same month and skip the oldest one.
+	cursor := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	for i := 0; i < n; i++ {
+		months = append(months, cursor.Format(_monthFormat))
+		cursor = cursor.AddDate(0, -1, 0)
+	}
+	return months
+}
+
+func (t *Trasher) trash(ctx context.Context, key string) error {
+	rc, err := t.store.GetObjectStream(ctx, key)
+	t.metrics.ObserveRequest("get", err)
+	if err != nil {
+		return fmt.Errorf("trasher: failed to read %s: %w", key, err)
+	}
+	defer rc.Close()
+
+	trashKey := _trashPrefix + key
+	err = t.store.PutObject(ctx, trashKey, rc, nil)
+	t.metrics.ObserveRequest("put", err)
+	if err != nil {
+		return fmt.Errorf("trasher: failed to move %s to trash: %w", key, err)
+	}
+	err = t.store.DeleteObject(ctx, key)
+	t.metrics.ObserveRequest("delete", err)
+	if err != nil {
+		return fmt.Errorf("trasher: failed to delete %s after trashing: %w", key, err)
+	}
+	logutil.Debugf(ctx, "trasher: moved %s to %s", key, trashKey)
+	return nil
+}
+
+// EmptyTrash permanently deletes objects under trash/ that have sat there
+// for longer than TrashLifetime, judged by ListObjects' LastModified (the
+// time trash() moved them there, since PutObject re-stamps it) rather than
+// a metadata key, as ObjectInfo carries no metadata back from any driver.
+func (t *Trasher) EmptyTrash(ctx context.Context) error {
+	objs, err := t.store.ListObjects(ctx, _trashPrefix)
+	if err != nil {
+		return fmt.Errorf("trasher: failed to list %s: %w", _trashPrefix, err)
+	}
+	for _, obj := range objs {
+		if time.Since(obj.LastModified) < t.cfg.TrashLifetime {
+			continue
+		}
+		err := t.store.DeleteObject(ctx, obj.Key)
+		t.metrics.ObserveRequest("delete", err)
+		if err != nil {
+			return fmt.Errorf("trasher: failed to empty %s: %w", obj.Key, err)
+		}
+	}
+	return nil
+}
+
+// UntrashObject restores a previously trashed object back to its original
+// key, undoing a Sweep move before EmptyTrash permanently removes it.
+func (t *Trasher) UntrashObject(ctx context.Context, key string) error {
+	trashKey := _trashPrefix + key
+	rc, err := t.store.GetObjectStream(ctx, trashKey)
+	t.metrics.ObserveRequest("get", err)
+	if err != nil {
+		return fmt.Errorf("trasher: failed to read %s: %w", trashKey, err)
+	}
+	defer rc.Close()
+
+	err = t.store.PutObject(ctx, key, rc, nil)
+	t.metrics.ObserveRequest("put", err)
+	if err != nil {
+		return fmt.Errorf("trasher: failed to restore %s: %w", key, err)
+	}
+	err = t.store.DeleteObject(ctx, trashKey)
+	t.metrics.ObserveRequest("delete", err)
+	return err
+}
@@ -0,0 +1,156 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/horizoncd/horizon/pkg/objectstore"
+)
+
+func putObject(t *testing.T, store objectstore.ObjectStore, key string) {
+	t.Helper()
+	if err := store.PutObject(context.Background(), key, bytes.NewReader([]byte("data")), nil); err != nil {
+		t.Fatalf("PutObject(%q): %v", key, err)
+	}
+}
+
+func TestTrasherSweepMovesStaleObjectsToTrash(t *testing.T) {
+	store := objectstore.NewFake()
+	ctx := context.Background()
+	key := time.Now().Format(_monthFormat) + "/" + _collectedSegmentPr + "/app-1/cluster-2/name"
+	putObject(t, store, key)
+
+	trasher := NewTrasher(store, TrasherConfig{RetentionDuration: 0}, nil)
+	if err := trasher.Sweep(ctx); err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+
+	if _, err := store.GetObject(ctx, key); !errors.Is(err, objectstore.ErrNotFound) {
+		t.Fatalf("GetObject(%q) after sweep = %v, want ErrNotFound", key, err)
+	}
+	if _, err := store.GetObject(ctx, _trashPrefix+key); err != nil {
+		t.Fatalf("GetObject(%q) = %v, want it to have landed in trash", _trashPrefix+key, err)
+	}
+}
+
+func TestTrasherSweepLeavesFreshObjectsInPlace(t *testing.T) {
+	store := objectstore.NewFake()
+	ctx := context.Background()
+	key := time.Now().Format(_monthFormat) + "/" + _collectedSegmentPrLog + "/app-1/cluster-2/name"
+	putObject(t, store, key)
+
+	trasher := NewTrasher(store, TrasherConfig{RetentionDuration: time.Hour}, nil)
+	if err := trasher.Sweep(ctx); err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+
+	if _, err := store.GetObject(ctx, key); err != nil {
+		t.Fatalf("GetObject(%q) after sweep = %v, want it untouched", key, err)
+	}
+	if _, err := store.GetObject(ctx, _trashPrefix+key); !errors.Is(err, objectstore.ErrNotFound) {
+		t.Fatalf("GetObject(%q) = %v, want ErrNotFound", _trashPrefix+key, err)
+	}
+}
+
+func TestTrasherSweepUnsafeDeleteSkipsTrash(t *testing.T) {
+	store := objectstore.NewFake()
+	ctx := context.Background()
+	key := time.Now().Format(_monthFormat) + "/" + _collectedSegmentPr + "/app-1/cluster-2/name"
+	putObject(t, store, key)
+
+	trasher := NewTrasher(store, TrasherConfig{RetentionDuration: 0, UnsafeDelete: true}, nil)
+	if err := trasher.Sweep(ctx); err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+
+	if _, err := store.GetObject(ctx, key); !errors.Is(err, objectstore.ErrNotFound) {
+		t.Fatalf("GetObject(%q) after sweep = %v, want ErrNotFound", key, err)
+	}
+	if _, err := store.GetObject(ctx, _trashPrefix+key); !errors.Is(err, objectstore.ErrNotFound) {
+		t.Fatalf("GetObject(%q) = %v, want UnsafeDelete to skip trash entirely", _trashPrefix+key, err)
+	}
+}
+
+func TestTrasherEmptyTrashRemovesExpiredObjects(t *testing.T) {
+	store := objectstore.NewFake()
+	ctx := context.Background()
+	key := _trashPrefix + "202607/pr/app-1/cluster-2/name"
+	putObject(t, store, key)
+
+	trasher := NewTrasher(store, TrasherConfig{TrashLifetime: 0}, nil)
+	if err := trasher.EmptyTrash(ctx); err != nil {
+		t.Fatalf("EmptyTrash: %v", err)
+	}
+	if _, err := store.GetObject(ctx, key); !errors.Is(err, objectstore.ErrNotFound) {
+		t.Fatalf("GetObject(%q) after EmptyTrash = %v, want ErrNotFound", key, err)
+	}
+}
+
+func TestTrasherEmptyTrashLeavesRecentlyTrashedObjects(t *testing.T) {
+	store := objectstore.NewFake()
+	ctx := context.Background()
+	key := _trashPrefix + "202607/pr/app-1/cluster-2/name"
+	putObject(t, store, key)
+
+	trasher := NewTrasher(store, TrasherConfig{TrashLifetime: time.Hour}, nil)
+	if err := trasher.EmptyTrash(ctx); err != nil {
+		t.Fatalf("EmptyTrash: %v", err)
+	}
+	if _, err := store.GetObject(ctx, key); err != nil {
+		t.Fatalf("GetObject(%q) after EmptyTrash = %v, want it untouched", key, err)
+	}
+}
+
+func TestTrasherUntrashObjectRestoresOriginalKey(t *testing.T) {
+	store := objectstore.NewFake()
+	ctx := context.Background()
+	key := time.Now().Format(_monthFormat) + "/" + _collectedSegmentPr + "/app-1/cluster-2/name"
+	putObject(t, store, key)
+
+	trasher := NewTrasher(store, TrasherConfig{RetentionDuration: 0}, nil)
+	if err := trasher.Sweep(ctx); err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if err := trasher.UntrashObject(ctx, key); err != nil {
+		t.Fatalf("UntrashObject: %v", err)
+	}
+
+	if _, err := store.GetObject(ctx, key); err != nil {
+		t.Fatalf("GetObject(%q) after untrash = %v, want it restored", key, err)
+	}
+	if _, err := store.GetObject(ctx, _trashPrefix+key); !errors.Is(err, objectstore.ErrNotFound) {
+		t.Fatalf("GetObject(%q) after untrash = %v, want ErrNotFound", _trashPrefix+key, err)
+	}
+}
+
+func TestSweepMonthsCoversRetentionWindow(t *testing.T) {
+	now := time.Date(2026, time.July, 29, 0, 0, 0, 0, time.UTC)
+	months := sweepMonths(now, 45*24*time.Hour)
+
+	want := []string{"202607", "202606", "202605"}
+	if len(months) != len(want) {
+		t.Fatalf("sweepMonths = %v, want %v", months, want)
+	}
+	for i, m := range want {
+		if months[i] != m {
+			t.Fatalf("sweepMonths[%d] = %q, want %q", i, months[i], m)
+		}
+	}
+}
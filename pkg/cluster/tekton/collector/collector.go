@@ -0,0 +1,47 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"io"
+
+	prmodels "github.com/horizoncd/horizon/pkg/pipelinerun/models"
+	"github.com/horizoncd/horizon/pkg/server/global"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+// Interface is what callers use to archive a pipelinerun's log/object and
+// read them back, independent of which ObjectStore backend S3Collector was
+// built with.
+type Interface interface {
+	Collect(ctx context.Context, pr *v1beta1.PipelineRun, horizonMetaData *global.HorizonMetaData) (*CollectResult, error)
+	GetPipelineRunLog(ctx context.Context, pr *prmodels.Pipelinerun) (*Log, error)
+	// GetPipelineRunLogStream streams the pipelineRun log back to the caller
+	// instead of buffering it into a []byte, so very large build logs don't
+	// have to fit in memory on the way out either.
+	GetPipelineRunLogStream(ctx context.Context, pr *prmodels.Pipelinerun) (io.ReadCloser, error)
+	GetPipelineRunObject(ctx context.Context, object string) (*Object, error)
+	GetPipelineRun(ctx context.Context, pr *prmodels.Pipelinerun) (*v1beta1.PipelineRun, error)
+	// EmptyTrash permanently removes collected artifacts that have sat in
+	// the object store's trash/ prefix longer than the configured
+	// TrashLifetime.
+	EmptyTrash(ctx context.Context) error
+	// UntrashObject restores a collected artifact that Sweep moved to
+	// trash/ back to its original key, before EmptyTrash can permanently
+	// remove it.
+	UntrashObject(ctx context.Context, key string) error
+}
@@ -18,9 +18,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"os"
 	"path"
@@ -28,19 +28,19 @@ import (
 	"time"
 
 	herrors "github.com/horizoncd/horizon/core/errors"
+	clustercollector "github.com/horizoncd/horizon/pkg/cluster/collector"
 	perror "github.com/horizoncd/horizon/pkg/errors"
 	prmodels "github.com/horizoncd/horizon/pkg/pipelinerun/models"
 	"github.com/horizoncd/horizon/pkg/server/global"
 
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	"gopkg.in/natefinch/lumberjack.v2"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"github.com/horizoncd/horizon/lib/s3"
 	"github.com/horizoncd/horizon/pkg/cluster/tekton"
+	"github.com/horizoncd/horizon/pkg/objectstore"
 	logutil "github.com/horizoncd/horizon/pkg/util/log"
 	"github.com/horizoncd/horizon/pkg/util/wlog"
 )
@@ -60,13 +60,39 @@ const (
 	_limitSize = _mb * 2.5
 )
 
+// S3Collector is the default Interface implementation. Despite the name it
+// archives pipelinerun logs/objects to whichever objectstore.ObjectStore it
+// is given -- s3, filesystem, or any other registered driver -- the name is
+// kept for historical callers that construct it directly with an s3.Interface.
 type S3Collector struct {
-	s3     s3.Interface
-	tekton tekton.Interface
-	logger *log.Logger
+	store   objectstore.ObjectStore
+	tekton  tekton.Interface
+	logger  *log.Logger
+	metrics *collectorMetrics
+	// trasher is nil unless the collector was built with NewCollectorWithTrasher;
+	// EmptyTrash/UntrashObject report herrors.ErrParamInvalid without one.
+	trasher *clustercollector.Trasher
 }
 
-func NewS3Collector(s3 s3.Interface, tekton tekton.Interface) Interface {
+// NewS3Collector builds a collector around an already-constructed
+// objectstore.ObjectStore, e.g. one returned by the "s3" driver's
+// lib/s3.New. Prefer NewCollector when the backend should be selected from
+// config instead of wired up by the caller. Metrics are created but not
+// registered with Prometheus; use NewS3CollectorWithMetrics to scrape them.
+func NewS3Collector(store objectstore.ObjectStore, tekton tekton.Interface) Interface {
+	return newS3Collector(store, tekton, nil)
+}
+
+// NewS3CollectorWithMetrics is NewS3Collector plus registration of the
+// collector's Prometheus metrics (collect_duration_seconds,
+// s3_requests_total, bytes_collected) with reg, so they're scraped
+// off Horizon's /metrics endpoint.
+func NewS3CollectorWithMetrics(store objectstore.ObjectStore, tekton tekton.Interface,
+	reg prometheus.Registerer) Interface {
+	return newS3Collector(store, tekton, reg)
+}
+
+func newS3Collector(store objectstore.ObjectStore, tekton tekton.Interface, reg prometheus.Registerer) Interface {
 	dir := getEnvOrDefault(_envKeyPipelineRunLogDIR, _defaultPipelineRunLogDir)
 	filename := getEnvOrDefault(_envKeyPipelineRunLogFile, _defaultPipelineRunLogFile)
 	output := lumberjack.Logger{
@@ -78,10 +104,37 @@ func NewS3Collector(s3 s3.Interface, tekton tekton.Interface) Interface {
 	}
 	logger := log.New(&output, "", log.LstdFlags)
 	return &S3Collector{
-		s3:     s3,
-		tekton: tekton,
-		logger: logger,
+		store:   store,
+		tekton:  tekton,
+		logger:  logger,
+		metrics: newCollectorMetrics(reg),
+	}
+}
+
+// NewCollector builds a collector around the objectstore.ObjectStore named
+// by cfg.Driver ("s3", "filesystem", ...), so the backend Horizon archives
+// pipelinerun history to is a config choice instead of a compile-time one.
+func NewCollector(cfg *objectstore.Config, tekton tekton.Interface, reg prometheus.Registerer) (Interface, error) {
+	store, err := objectstore.New(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return NewS3CollectorWithMetrics(store, tekton, reg), nil
+}
+
+// NewCollectorWithTrasher is NewCollector plus a Trasher wired up to sweep
+// the same object store, so EmptyTrash/UntrashObject have something to
+// delegate to. Run the sweep itself from core/controller/collector.
+func NewCollectorWithTrasher(cfg *objectstore.Config, tekton tekton.Interface, reg prometheus.Registerer,
+	trasherCfg clustercollector.TrasherConfig) (Interface, *clustercollector.Trasher, error) {
+	store, err := objectstore.New(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := newS3Collector(store, tekton, reg).(*S3Collector)
+	trasher := clustercollector.NewTrasher(store, trasherCfg, c.metrics)
+	c.trasher = trasher
+	return c, trasher, nil
 }
 
 func getEnvOrDefault(envKey string, defaultValue string) string {
@@ -162,7 +215,7 @@ func (c *S3Collector) Collect(ctx context.Context, pr *v1beta1.PipelineRun, hori
 	c.logger.Println(string(b))
 
 	collectResult := &CollectResult{
-		Bucket:         c.s3.GetBucket(ctx),
+		Bucket:         c.store.GetBucket(ctx),
 		LogObject:      collectLogResult.LogObject,
 		PrObject:       collectObjectResult.PrObject,
 		Result:         metadata.PipelineRun.Result,
@@ -171,7 +224,7 @@ func (c *S3Collector) Collect(ctx context.Context, pr *v1beta1.PipelineRun, hori
 	}
 
 	// delete pipelinerun in k8s
-	if err := c.tekton.DeletePipelineRun(ctx, pr); err != nil {
+	if err := c.deletePipelineRun(ctx, pr); err != nil {
 		if _, ok := perror.Cause(err).(*herrors.HorizonErrNotFound); ok {
 			logutil.Warningf(ctx, "received pipelineRun: %v is not found when deleted", pr.Name)
 			return collectResult, nil
@@ -184,6 +237,12 @@ func (c *S3Collector) Collect(ctx context.Context, pr *v1beta1.PipelineRun, hori
 	return collectResult, nil
 }
 
+func (c *S3Collector) deletePipelineRun(ctx context.Context, pr *v1beta1.PipelineRun) (err error) {
+	defer func(start time.Time) { c.metrics.observe("delete", err, start) }(time.Now())
+	err = c.tekton.DeletePipelineRun(ctx, pr)
+	return err
+}
+
 func (c *S3Collector) GetPipelineRunLog(ctx context.Context, pr *prmodels.Pipelinerun) (*Log, error) {
 	const op = "s3Collector: getPipelineRunLog"
 	defer wlog.Start(ctx, op).StopPrint()
@@ -210,16 +269,55 @@ func (c *S3Collector) GetPipelineRunLog(ctx context.Context, pr *prmodels.Pipeli
 	}, nil
 }
 
+// GetPipelineRunLogStream streams the pipelineRun log back to the caller
+// instead of buffering it into a []byte, so very large build logs don't
+// have to fit in memory on the way out either.
+func (c *S3Collector) GetPipelineRunLogStream(ctx context.Context, pr *prmodels.Pipelinerun) (_ io.ReadCloser, err error) {
+	const op = "s3Collector: getPipelineRunLogStream"
+	defer wlog.Start(ctx, op).StopPrint()
+	defer func() { c.metrics.ObserveRequest("get", err) }()
+
+	if pr.PrObject == "" {
+		return nil, herrors.NewErrNotFound(herrors.PipelinerunLog, "pipelineRun log is not collected to s3 yet")
+	}
+
+	rc, err := c.store.GetObjectStream(ctx, pr.LogObject)
+	if err != nil {
+		if errors.Is(err, objectstore.ErrNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.PipelinerunLog, err.Error())
+		}
+		return nil, perror.Wrap(herrors.ErrS3GetObjFailed, err.Error())
+	}
+	return rc, nil
+}
+
+// EmptyTrash permanently removes collected artifacts that have sat in the
+// object store's trash/ prefix longer than the configured TrashLifetime.
+func (c *S3Collector) EmptyTrash(ctx context.Context) error {
+	if c.trasher == nil {
+		return perror.Wrap(herrors.ErrParamInvalid, "collector was not built with a trasher")
+	}
+	return c.trasher.EmptyTrash(ctx)
+}
+
+// UntrashObject restores a collected artifact that Sweep moved to trash/
+// back to its original key, before EmptyTrash can permanently remove it.
+func (c *S3Collector) UntrashObject(ctx context.Context, key string) error {
+	if c.trasher == nil {
+		return perror.Wrap(herrors.ErrParamInvalid, "collector was not built with a trasher")
+	}
+	return c.trasher.UntrashObject(ctx, key)
+}
+
 func (c *S3Collector) getPipelineRunLog(ctx context.Context, logObject string) (_ []byte, err error) {
 	const op = "s3Collector: getPipelineRunLog from s3"
 	defer wlog.Start(ctx, op).StopPrint()
+	defer func() { c.metrics.ObserveRequest("get", err) }()
 
-	b, err := c.s3.GetObject(ctx, logObject)
+	b, err := c.store.GetObject(ctx, logObject)
 	if err != nil {
-		if e, ok := err.(awserr.Error); ok {
-			if e.Code() == awss3.ErrCodeNoSuchKey {
-				return nil, herrors.NewErrNotFound(herrors.PipelinerunLog, err.Error())
-			}
+		if errors.Is(err, objectstore.ErrNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.PipelinerunLog, err.Error())
 		}
 		return nil, perror.Wrap(herrors.ErrS3GetObjFailed, err.Error())
 	}
@@ -229,13 +327,12 @@ func (c *S3Collector) getPipelineRunLog(ctx context.Context, logObject string) (
 func (c *S3Collector) GetPipelineRunObject(ctx context.Context, object string) (_ *Object, err error) {
 	const op = "s3Collector: getPipelineRunObject"
 	defer wlog.Start(ctx, op).StopPrint()
+	defer func() { c.metrics.ObserveRequest("get", err) }()
 
-	b, err := c.s3.GetObject(ctx, object)
+	b, err := c.store.GetObject(ctx, object)
 	if err != nil {
-		if e, ok := err.(awserr.Error); ok {
-			if e.Code() == awss3.ErrCodeNoSuchKey {
-				return nil, herrors.NewErrNotFound(herrors.PipelinerunObj, err.Error())
-			}
+		if errors.Is(err, objectstore.ErrNotFound) {
+			return nil, herrors.NewErrNotFound(herrors.PipelinerunObj, err.Error())
 		}
 		return nil, perror.Wrap(herrors.ErrS3GetObjFailed, err.Error())
 	}
@@ -280,6 +377,8 @@ func (c *S3Collector) collectObject(ctx context.Context, metadata *ObjectMeta,
 	pr *v1beta1.PipelineRun) (_ *CollectObjectResult, err error) {
 	const op = "s3Collector: collectObject"
 	defer wlog.Start(ctx, op).StopPrint()
+	defer func(start time.Time) { c.metrics.observe("object", err, start) }(time.Now())
+
 	object := &Object{
 		Metadata:    metadata,
 		PipelineRun: pr,
@@ -290,13 +389,17 @@ func (c *S3Collector) collectObject(ctx context.Context, metadata *ObjectMeta,
 	}
 	prPath := c.getPathForPr(metadata)
 
-	prURL, err := c.s3.GetSignedObjectURL(prPath, _expireTimeDuration)
+	prURL, err := c.store.GetSignedObjectURL(prPath, _expireTimeDuration)
+	c.metrics.ObserveRequest("sign", err)
 	if err != nil {
 		return nil, perror.Wrap(herrors.ErrS3SignFailed, err.Error())
 	}
-	if err := c.s3.PutObject(ctx, prPath, bytes.NewReader(b), c.resolveMetadata(metadata)); err != nil {
+	err = c.store.PutObject(ctx, prPath, bytes.NewReader(b), c.resolveMetadata(metadata))
+	c.metrics.ObserveRequest("put", err)
+	if err != nil {
 		return nil, perror.Wrap(herrors.ErrS3PutObjFailed, err.Error())
 	}
+	c.metrics.addBytes(metadata.Application, metadata.Cluster, len(b))
 	return &CollectObjectResult{
 		PrObject: prPath,
 		PrURL:    prURL,
@@ -313,6 +416,7 @@ func (c *S3Collector) collectLog(ctx context.Context,
 	pr *v1beta1.PipelineRun, metadata *ObjectMeta) (_ *CollectLogResult, err error) {
 	const op = "s3Collector: collectLog"
 	defer wlog.Start(ctx, op).StopPrint()
+	defer func(start time.Time) { c.metrics.observe("log", err, start) }(time.Now())
 
 	logC, errC, err := c.tekton.GetPipelineRunLog(ctx, pr)
 	if err != nil {
@@ -348,25 +452,59 @@ func (c *S3Collector) collectLog(ctx context.Context,
 
 	logPath := c.getPathForPrLog(metadata)
 
-	logURL, err := c.s3.GetSignedObjectURL(logPath, _expireTimeDuration)
+	logURL, err := c.store.GetSignedObjectURL(logPath, _expireTimeDuration)
+	c.metrics.ObserveRequest("sign", err)
 	if err != nil {
 		return nil, perror.Wrap(herrors.ErrS3SignFailed, err.Error())
 	}
 
-	b, err := ioutil.ReadAll(r)
+	// Only the first _limitSize bytes are kept around for LogContent (it ends
+	// up embedded in the JSON line Collect writes to c.logger); the rest
+	// streams straight from the pipe into the store's PutObject -- the "s3"
+	// driver uploads it via s3manager as parts arrive -- instead of requiring
+	// the whole log to be buffered here first.
+	head := newHeadBuffer(_limitSize)
+	err = c.store.PutObject(ctx, logPath, io.TeeReader(r, head), nil)
+	c.metrics.ObserveRequest("put", err)
 	if err != nil {
-		return nil, perror.Wrap(herrors.ErrReadFailed, err.Error())
-	}
-	if err := c.s3.PutObject(ctx, logPath, bytes.NewReader(b), nil); err != nil {
 		return nil, perror.Wrap(herrors.ErrS3PutObjFailed, err.Error())
 	}
+	c.metrics.addBytes(metadata.Application, metadata.Cluster, head.total)
 	return &CollectLogResult{
 		LogObject:  logPath,
 		LogURL:     logURL,
-		LogContent: string(b),
+		LogContent: head.String(),
 	}, nil
 }
 
+// headBuffer captures only the first limit bytes written to it and discards
+// the rest, so a TeeReader can be used to sample a stream without buffering
+// it in full. total tracks the full stream length regardless of limit.
+type headBuffer struct {
+	buf   bytes.Buffer
+	limit int
+	total int
+}
+
+func newHeadBuffer(limit int) *headBuffer {
+	return &headBuffer{limit: limit}
+}
+
+func (h *headBuffer) Write(p []byte) (int, error) {
+	h.total += len(p)
+	if remaining := h.limit - h.buf.Len(); remaining > 0 {
+		if remaining > len(p) {
+			remaining = len(p)
+		}
+		h.buf.Write(p[:remaining])
+	}
+	return len(p), nil
+}
+
+func (h *headBuffer) String() string {
+	return h.buf.String()
+}
+
 func (c *S3Collector) getPathForPr(metadata *ObjectMeta) string {
 	timeFormat := "200601"
 	timeStr := time.Now().Format(timeFormat)
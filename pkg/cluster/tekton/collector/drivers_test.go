@@ -0,0 +1,41 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/horizoncd/horizon/pkg/objectstore"
+)
+
+func TestS3DriverRegistered(t *testing.T) {
+	store, err := objectstore.New(&objectstore.Config{
+		Driver: "s3",
+		S3: objectstore.S3Config{
+			Bucket:     "test-bucket",
+			Region:     "us-east-1",
+			AuthMethod: "static",
+			AccessKey:  "ak",
+			SecretKey:  "sk",
+		},
+	})
+	if err != nil {
+		t.Fatalf(`objectstore.New(Driver: "s3"): %v`, err)
+	}
+	if got := store.GetBucket(context.Background()); got != "test-bucket" {
+		t.Fatalf("GetBucket() = %q, want %q", got, "test-bucket")
+	}
+}
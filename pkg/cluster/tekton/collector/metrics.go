@@ -0,0 +1,88 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/horizoncd/horizon/pkg/objectstore"
+)
+
+// collectorMetrics holds the Prometheus vectors S3Collector reports
+// through, so operators can scrape collector health off Horizon's
+// /metrics endpoint and alert on rising latency or NotFound rates.
+type collectorMetrics struct {
+	collectDuration *prometheus.HistogramVec
+	s3Requests      *prometheus.CounterVec
+	bytesCollected  *prometheus.GaugeVec
+}
+
+func newCollectorMetrics(reg prometheus.Registerer) *collectorMetrics {
+	m := &collectorMetrics{
+		collectDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "collect_duration_seconds",
+			Help:    "Time taken by a collector operation, by phase and outcome.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"phase", "result"}),
+		s3Requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "s3_requests_total",
+			Help: "Object store requests issued by the collector, by operation and outcome.",
+		}, []string{"op", "code"}),
+		bytesCollected: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "bytes_collected",
+			Help: "Cumulative bytes written to the object store, by application and cluster.",
+		}, []string{"application", "cluster"}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.collectDuration, m.s3Requests, m.bytesCollected)
+	}
+	return m
+}
+
+// observe records how long a collector phase (log, object, delete) took and
+// whether it succeeded. Call as defer func() { observe(...) }() so err is
+// read after the named return value is set.
+func (m *collectorMetrics) observe(phase string, err error, start time.Time) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.collectDuration.WithLabelValues(phase, result).Observe(time.Since(start).Seconds())
+}
+
+// ObserveRequest records a single object-store call (get/put/sign/delete).
+// It also implements clustercollector.MetricsRecorder, so a Trasher built
+// with these metrics folds its own get/put/delete calls into the same
+// s3_requests_total series.
+func (m *collectorMetrics) ObserveRequest(op string, err error) {
+	code := "success"
+	if err != nil {
+		code = "error"
+		if errors.Is(err, objectstore.ErrNotFound) {
+			code = "not_found"
+		}
+	}
+	m.s3Requests.WithLabelValues(op, code).Inc()
+}
+
+func (m *collectorMetrics) addBytes(application, cluster string, n int) {
+	if n <= 0 {
+		return
+	}
+	m.bytesCollected.WithLabelValues(application, cluster).Add(float64(n))
+}
@@ -0,0 +1,94 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/horizoncd/horizon/pkg/objectstore"
+)
+
+// histogramSampleCount reads back how many observations a labelled child of
+// a HistogramVec has recorded, so observe's phase/result labelling can be
+// asserted without hand-rolling a full exposition-format comparison.
+func histogramSampleCount(t *testing.T, o prometheus.Observer) uint64 {
+	t.Helper()
+	metric, ok := o.(prometheus.Metric)
+	if !ok {
+		t.Fatalf("observer %T does not implement prometheus.Metric", o)
+	}
+	var m dto.Metric
+	if err := metric.Write(&m); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestCollectorMetricsObserve(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newCollectorMetrics(reg)
+
+	m.observe("log", nil, time.Now())
+	m.observe("object", errors.New("boom"), time.Now())
+
+	if got := histogramSampleCount(t, m.collectDuration.WithLabelValues("log", "success")); got != 1 {
+		t.Fatalf(`collect_duration_seconds{phase="log",result="success"} sample count = %d, want 1`, got)
+	}
+	if got := histogramSampleCount(t, m.collectDuration.WithLabelValues("object", "error")); got != 1 {
+		t.Fatalf(`collect_duration_seconds{phase="object",result="error"} sample count = %d, want 1`, got)
+	}
+	if got := histogramSampleCount(t, m.collectDuration.WithLabelValues("log", "error")); got != 0 {
+		t.Fatalf(`collect_duration_seconds{phase="log",result="error"} sample count = %d, want 0`, got)
+	}
+}
+
+func TestCollectorMetricsObserveRequest(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newCollectorMetrics(reg)
+
+	m.ObserveRequest("get", nil)
+	m.ObserveRequest("get", objectstore.ErrNotFound)
+	m.ObserveRequest("put", errors.New("connection reset"))
+
+	if got := testutil.ToFloat64(m.s3Requests.WithLabelValues("get", "success")); got != 1 {
+		t.Fatalf(`s3_requests_total{op="get",code="success"} = %v, want 1`, got)
+	}
+	if got := testutil.ToFloat64(m.s3Requests.WithLabelValues("get", "not_found")); got != 1 {
+		t.Fatalf(`s3_requests_total{op="get",code="not_found"} = %v, want 1`, got)
+	}
+	if got := testutil.ToFloat64(m.s3Requests.WithLabelValues("put", "error")); got != 1 {
+		t.Fatalf(`s3_requests_total{op="put",code="error"} = %v, want 1`, got)
+	}
+}
+
+func TestCollectorMetricsAddBytes(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newCollectorMetrics(reg)
+
+	m.addBytes("app-1", "cluster-2", 128)
+	m.addBytes("app-1", "cluster-2", 64)
+	m.addBytes("app-1", "cluster-2", 0)
+
+	if got := testutil.ToFloat64(m.bytesCollected.WithLabelValues("app-1", "cluster-2")); got != 192 {
+		t.Fatalf(`bytes_collected{application="app-1",cluster="cluster-2"} = %v, want 192`, got)
+	}
+}
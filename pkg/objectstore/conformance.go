@@ -0,0 +1,75 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+// ConformanceTest exercises the Put/Get/GetObjectStream/List/Delete contract
+// every ObjectStore driver must satisfy, against an already-constructed
+// store. It lives in a regular (non-_test.go) file, rather than as an
+// unexported helper in e.g. fake_test.go, so driver packages outside
+// pkg/objectstore -- lib/s3's "s3" driver in particular -- can reuse it
+// from their own tests instead of re-deriving the same assertions.
+func ConformanceTest(t *testing.T, store ObjectStore) {
+	t.Helper()
+	ctx := context.Background()
+	const key = "202607/pr/app-1/cluster-2/name"
+
+	if err := store.PutObject(ctx, key, bytes.NewReader([]byte("hello")), nil); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+
+	b, err := store.GetObject(ctx, key)
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	if string(b) != "hello" {
+		t.Fatalf("GetObject = %q, want %q", b, "hello")
+	}
+
+	rc, err := store.GetObjectStream(ctx, key)
+	if err != nil {
+		t.Fatalf("GetObjectStream: %v", err)
+	}
+	streamed, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatalf("read stream: %v", err)
+	}
+	if string(streamed) != "hello" {
+		t.Fatalf("GetObjectStream = %q, want %q", streamed, "hello")
+	}
+
+	infos, err := store.ListObjects(ctx, "202607/pr/")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Key != key {
+		t.Fatalf("ListObjects = %+v, want a single entry for %q", infos, key)
+	}
+
+	if err := store.DeleteObject(ctx, key); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if _, err := store.GetObject(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetObject after delete = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	Register("fake", newFakeStore)
+}
+
+// NewFake returns an in-memory ObjectStore for use in unit tests, in place
+// of standing up a real S3-compatible service or mocking the aws SDK.
+func NewFake() ObjectStore {
+	return &fakeStore{objects: map[string][]byte{}, modified: map[string]time.Time{}}
+}
+
+func newFakeStore(_ *Config) (ObjectStore, error) {
+	return NewFake(), nil
+}
+
+type fakeStore struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	modified map[string]time.Time
+}
+
+func (f *fakeStore) PutObject(_ context.Context, key string, body io.Reader, _ map[string]string) error {
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[key] = b
+	f.modified[key] = time.Now()
+	return nil
+}
+
+func (f *fakeStore) GetObject(_ context.Context, key string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, ok := f.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return b, nil
+}
+
+func (f *fakeStore) GetObjectStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	b, err := f.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (f *fakeStore) GetSignedObjectURL(key string, _ time.Duration) (string, error) {
+	return fmt.Sprintf("fake://%s", key), nil
+}
+
+func (f *fakeStore) DeleteObject(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.objects[key]; !ok {
+		return ErrNotFound
+	}
+	delete(f.objects, key)
+	delete(f.modified, key)
+	return nil
+}
+
+func (f *fakeStore) GetBucket(_ context.Context) string {
+	return "fake-bucket"
+}
+
+func (f *fakeStore) ListObjects(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var infos []ObjectInfo
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, ObjectInfo{Key: key, LastModified: f.modified[key]})
+		}
+	}
+	return infos, nil
+}
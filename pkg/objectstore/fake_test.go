@@ -0,0 +1,42 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFakeStorePutGetDelete(t *testing.T) {
+	ConformanceTest(t, NewFake())
+}
+
+func TestFakeStoreGetObjectNotFound(t *testing.T) {
+	store := NewFake()
+	if _, err := store.GetObject(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetObject = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFakeStoreRegisteredAsDriver(t *testing.T) {
+	store, err := New(&Config{Driver: "fake"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if store.GetBucket(context.Background()) == "" {
+		t.Fatalf("New(%q) did not return a usable ObjectStore", "fake")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstore
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("filesystem", newFilesystemStore)
+}
+
+// filesystemStore writes objects as plain files under a root directory,
+// keyed the same way the "s3" driver keys them (dir/200601/pr/...). It
+// exists for local dev clusters and small on-prem deployments that don't
+// want to run an S3-compatible service.
+type filesystemStore struct {
+	dir string
+}
+
+func newFilesystemStore(cfg *Config) (ObjectStore, error) {
+	if err := os.MkdirAll(cfg.Filesystem.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &filesystemStore{dir: cfg.Filesystem.Dir}, nil
+}
+
+func (f *filesystemStore) path(key string) string {
+	return filepath.Join(f.dir, filepath.FromSlash(key))
+}
+
+func (f *filesystemStore) PutObject(_ context.Context, key string, body io.Reader, _ map[string]string) error {
+	p := f.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	_, err = io.Copy(file, body)
+	return err
+}
+
+func (f *filesystemStore) GetObject(_ context.Context, key string) ([]byte, error) {
+	b, err := os.ReadFile(f.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return b, err
+}
+
+func (f *filesystemStore) GetObjectStream(_ context.Context, key string) (io.ReadCloser, error) {
+	file, err := os.Open(f.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (f *filesystemStore) GetSignedObjectURL(key string, _ time.Duration) (string, error) {
+	// There's no server to sign a request against, so just hand back a
+	// file:// URL to the on-disk path.
+	return (&url.URL{Scheme: "file", Path: f.path(key)}).String(), nil
+}
+
+func (f *filesystemStore) DeleteObject(_ context.Context, key string) error {
+	err := os.Remove(f.path(key))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (f *filesystemStore) GetBucket(_ context.Context) string {
+	return f.dir
+}
+
+func (f *filesystemStore) ListObjects(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	root := f.path(prefix)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		// A prefix that ends mid-name can match siblings, so walk its parent.
+		root = filepath.Dir(root)
+	}
+	var infos []ObjectInfo
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(f.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		infos = append(infos, ObjectInfo{
+			Key:          key,
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return infos, nil
+}
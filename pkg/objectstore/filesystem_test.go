@@ -0,0 +1,52 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newTestFilesystemStore(t *testing.T) ObjectStore {
+	t.Helper()
+	store, err := New(&Config{Driver: "filesystem", Filesystem: FilesystemConfig{Dir: t.TempDir()}})
+	if err != nil {
+		t.Fatalf(`New(Driver: "filesystem"): %v`, err)
+	}
+	return store
+}
+
+func TestFilesystemStorePutGetDelete(t *testing.T) {
+	ConformanceTest(t, newTestFilesystemStore(t))
+}
+
+func TestFilesystemStoreGetObjectNotFound(t *testing.T) {
+	store := newTestFilesystemStore(t)
+	if _, err := store.GetObject(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetObject = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFilesystemStoreListObjectsEmptyPrefix(t *testing.T) {
+	store := newTestFilesystemStore(t)
+	infos, err := store.ListObjects(context.Background(), "202607/pr/")
+	if err != nil {
+		t.Fatalf("ListObjects on an empty store: %v", err)
+	}
+	if len(infos) != 0 {
+		t.Fatalf("ListObjects = %+v, want none", infos)
+	}
+}
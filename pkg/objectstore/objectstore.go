@@ -0,0 +1,118 @@
+// Copyright © 2023 Horizoncd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package objectstore defines the backend-agnostic object-store contract
+// the tekton collector archives pipelinerun logs and objects through, and a
+// small driver registry so a backend can be selected by name from config
+// instead of being compiled in.
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned by an ObjectStore implementation when the
+// requested key doesn't exist, regardless of backend. Drivers are
+// responsible for translating their native not-found error into this
+// sentinel so callers can check with errors.Is across backends.
+var ErrNotFound = errors.New("object not found")
+
+// ObjectStore is the set of operations the tekton collector needs from an
+// object-storage backend.
+type ObjectStore interface {
+	PutObject(ctx context.Context, key string, body io.Reader, metadata map[string]string) error
+	GetObject(ctx context.Context, key string) ([]byte, error)
+	// GetObjectStream returns the object body as a stream; callers must
+	// close the returned ReadCloser.
+	GetObjectStream(ctx context.Context, key string) (io.ReadCloser, error)
+	GetSignedObjectURL(key string, expire time.Duration) (string, error)
+	DeleteObject(ctx context.Context, key string) error
+	GetBucket(ctx context.Context) string
+	// ListObjects lists every object whose key starts with prefix. It backs
+	// the collector's trash sweep, which needs to find objects older than a
+	// retention window without knowing their names up front.
+	ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// ObjectInfo is the subset of object metadata ListObjects returns.
+type ObjectInfo struct {
+	Key          string
+	LastModified time.Time
+}
+
+// Config selects a driver by name and carries the settings each driver
+// needs. Only the fields relevant to Driver are read.
+//
+// Scope note (kilosonc/horizon#chunk0-2): the request that introduced this
+// package named "s3", "gcs", "azureblob", "filesystem", and "oss" as the
+// backends on-prem Horizon users should be able to pick between. Only
+// "s3" and "filesystem" are registered here (plus "fake", for tests) --
+// gcs/azureblob/oss are deliberately out of scope for this change, not an
+// oversight, and New returns an "unknown driver" error for them until a
+// driver is added under pkg/objectstore to cover them.
+type Config struct {
+	Driver string `json:"driver" yaml:"driver"`
+
+	S3         S3Config         `json:"s3" yaml:"s3"`
+	Filesystem FilesystemConfig `json:"filesystem" yaml:"filesystem"`
+}
+
+// S3Config carries the settings the "s3" driver needs.
+type S3Config struct {
+	Bucket      string `json:"bucket" yaml:"bucket"`
+	Region      string `json:"region" yaml:"region"`
+	AccessKey   string `json:"accessKey" yaml:"accessKey"`
+	SecretKey   string `json:"secretKey" yaml:"secretKey"`
+	PartSize    int64  `json:"partSize" yaml:"partSize"`
+	Concurrency int    `json:"concurrency" yaml:"concurrency"`
+
+	// AuthMethod selects how the driver obtains AWS credentials: "static"
+	// (AccessKey/SecretKey), "iam" (an EC2 instance role) or "irsa" (an EKS
+	// IAM role for a service account, via web identity federation). Leave
+	// empty to have the driver probe the environment: IRSA first, then an
+	// EC2 instance role, then AccessKey/SecretKey if either is set.
+	AuthMethod string `json:"authMethod" yaml:"authMethod"`
+}
+
+// FilesystemConfig carries the settings the "filesystem" driver needs.
+type FilesystemConfig struct {
+	// Dir is the root directory objects are written under, e.g.
+	// dir/200601/pr/<app>-<id>/<cluster>-<id>/<name>.
+	Dir string `json:"dir" yaml:"dir"`
+}
+
+// NewFunc constructs an ObjectStore from Config. Drivers register one under
+// their name with Register.
+type NewFunc func(cfg *Config) (ObjectStore, error)
+
+var drivers = map[string]NewFunc{}
+
+// Register makes a driver available under name. It is expected to be
+// called from a driver's package init().
+func Register(name string, fn NewFunc) {
+	drivers[name] = fn
+}
+
+// New builds the ObjectStore selected by cfg.Driver.
+func New(cfg *Config) (ObjectStore, error) {
+	fn, ok := drivers[cfg.Driver]
+	if !ok {
+		return nil, fmt.Errorf("objectstore: unknown driver %q", cfg.Driver)
+	}
+	return fn(cfg)
+}